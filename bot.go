@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sppo555/webhook/telegram"
+)
+
+const (
+	pollTimeoutSeconds = 60
+	pollBackoff        = 3 * time.Second
+	ringBufferSize     = 10
+)
+
+var (
+	forwardLog = newForwardRing()
+	mutedPaths = newMuteState()
+)
+
+// startBotMode enables whichever of the two Telegram update-delivery modes
+// is configured: TG_POLL_MODE=1 runs a background long-polling loop, while
+// TG_WEBHOOK_PATH registers a handler for Telegram to push updates to
+// instead. The two are mutually exclusive.
+func startBotMode() {
+	pollMode := os.Getenv("TG_POLL_MODE") == "1"
+	webhookPath := os.Getenv("TG_WEBHOOK_PATH")
+
+	if pollMode && webhookPath != "" {
+		log.Fatal("TG_POLL_MODE and TG_WEBHOOK_PATH are mutually exclusive")
+	}
+
+	switch {
+	case pollMode:
+		go runPollLoop()
+	case webhookPath != "":
+		registerUpdateWebhook(webhookPath)
+	}
+}
+
+// runPollLoop calls getUpdates in a loop, surviving network errors with a
+// fixed backoff, for as long as the process runs.
+func runPollLoop() {
+	offset := 0
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(pollTimeoutSeconds)*time.Second+pollBackoff*2)
+		updates, err := tgClient.GetUpdates(ctx, offset, pollTimeoutSeconds)
+		cancel()
+
+		if err != nil {
+			log.Printf("Failed to get Telegram updates: %v", err)
+			time.Sleep(pollBackoff)
+			continue
+		}
+
+		for _, update := range updates {
+			offset = update.UpdateID + 1
+			handleUpdate(update)
+		}
+	}
+}
+
+// registerUpdateWebhook wires path to receive Telegram updates and, if
+// TG_WEBHOOK_URL is set, tells Telegram to start pushing to it.
+func registerUpdateWebhook(path string) {
+	http.HandleFunc(path, handleTelegramWebhook)
+
+	publicURL := os.Getenv("TG_WEBHOOK_URL")
+	if publicURL == "" {
+		log.Printf("TG_WEBHOOK_PATH is set but TG_WEBHOOK_URL is empty; skipping Telegram setWebhook call")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sinkTimeout)
+	defer cancel()
+	if err := tgClient.SetWebhook(ctx, publicURL+path); err != nil {
+		log.Printf("Failed to register Telegram webhook: %v", err)
+	}
+}
+
+func handleTelegramWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid HTTP method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var update telegram.APIUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Failed to decode update", http.StatusBadRequest)
+		return
+	}
+
+	handleUpdate(update)
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleUpdate routes an incoming update to a command handler and replies
+// in the same chat, if the update was a recognized command.
+func handleUpdate(update telegram.APIUpdate) {
+	if update.Message == nil || update.Message.Text == "" {
+		return
+	}
+
+	cmd, args := parseCommand(update.Message.Text)
+	reply := handleCommand(cmd, args)
+	if reply == "" {
+		return
+	}
+
+	chatID := strconv.FormatInt(update.Message.Chat.ID, 10)
+	ctx, cancel := context.WithTimeout(context.Background(), sinkTimeout)
+	defer cancel()
+	if err := tgClient.SendMessage(ctx, chatID, reply, telegram.SendOptions{}); err != nil {
+		log.Printf("Failed to reply to Telegram command /%s: %v", cmd, err)
+	}
+}
+
+func parseCommand(text string) (cmd string, args []string) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return "", nil
+	}
+
+	cmd = strings.TrimPrefix(fields[0], "/")
+	if at := strings.IndexByte(cmd, '@'); at != -1 { // strip the "@botname" suffix Telegram adds in groups
+		cmd = cmd[:at]
+	}
+	return cmd, fields[1:]
+}
+
+func handleCommand(cmd string, args []string) string {
+	switch cmd {
+	case "start", "help":
+		return "Available commands:\n" +
+			"/paths - list configured webhook paths\n" +
+			"/last <path> - show recently forwarded messages\n" +
+			"/mute <path> <duration> - suppress forwarding for a time window"
+	case "paths":
+		return listPaths()
+	case "last":
+		if len(args) != 1 {
+			return "Usage: /last <path>"
+		}
+		return formatLast(args[0])
+	case "mute":
+		if len(args) != 2 {
+			return "Usage: /mute <path> <duration>"
+		}
+		return mutePath(args[0], args[1])
+	default:
+		return ""
+	}
+}
+
+func listPaths() string {
+	// PathHandlers is keyed by "/"+path, and the built-in routes are added to
+	// that list already slash-prefixed, so their keys are "//webhook" and
+	// "//heartcheck" rather than WebhookPath/HeartcheckPath themselves.
+	excluded := map[string]bool{
+		"/" + WebhookPath:    true,
+		"/" + HeartcheckPath: true,
+	}
+
+	var paths []string
+	for path := range PathHandlers {
+		if excluded[path] {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	if len(paths) == 0 {
+		return "No dynamic paths configured."
+	}
+	return "Configured paths:\n" + strings.Join(paths, "\n")
+}
+
+func formatLast(path string) string {
+	path = normalizePath(path)
+	messages := forwardLog.last(path, ringBufferSize)
+	if len(messages) == 0 {
+		return fmt.Sprintf("No messages recorded yet for %s", path)
+	}
+	return fmt.Sprintf("Last %d message(s) for %s:\n\n%s", len(messages), path, strings.Join(messages, "\n---\n"))
+}
+
+func mutePath(path, durationStr string) string {
+	duration, err := time.ParseDuration(durationStr)
+	if err != nil {
+		return fmt.Sprintf("Invalid duration %q: %v", durationStr, err)
+	}
+
+	path = normalizePath(path)
+	mutedPaths.mute(path, duration)
+	return fmt.Sprintf("Muted %s for %s", path, duration)
+}
+
+// normalizePath turns a bare path like "grafana" into "/grafana", matching
+// the keys PathHandlers is indexed by.
+func normalizePath(path string) string {
+	if strings.HasPrefix(path, "/") {
+		return path
+	}
+	return "/" + path
+}
@@ -1,13 +1,18 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"strings"
+	"text/template"
+
+	"github.com/sppo555/webhook/telegram"
 )
 
 const (
@@ -20,7 +25,9 @@ var (
 	TGAPIToken   = os.Getenv("TG_API_TOKEN") // Telegram Bot API token
 	TGChatID     = os.Getenv("TG_CHAT_ID")   // Telegram chat ID
 	URLPath      = os.Getenv("URL_PATH")     // Path configuration
+	ParseMode    = parseModeFromEnv()        // Default Telegram parse mode
 	PathHandlers = make(map[string]http.HandlerFunc)
+	tgClient     = telegram.NewClient(TGAPIToken)
 )
 
 func main() {
@@ -34,10 +41,16 @@ func main() {
 		path = strings.TrimSpace(path)
 		if path != "" {
 			filterKeys := strings.Split(os.Getenv(strings.ToUpper(path)+"_FILTER_KEY"), ",")
-			PathHandlers["/"+path] = createDynamicHandler(filterKeys, path) // Set the handler for dynamic paths
+			tmpl, err := loadTemplate(path)
+			if err != nil {
+				log.Fatalf("Failed to load template for path %s: %v", path, err)
+			}
+			PathHandlers["/"+path] = createDynamicHandler(filterKeys, path, tmpl) // Set the handler for dynamic paths
 		}
 	}
 
+	startBotMode() // Start long-polling or register the update webhook, if configured
+
 	http.HandleFunc("/", handleNotFound) // Set the handler for not found paths
 
 	for path, handler := range PathHandlers {
@@ -59,6 +72,16 @@ func handleWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	body, ok := verifySignature(r, WebhookPath)
+	if !ok {
+		log.Printf("Rejected request to %s from %s: signature verification failed", WebhookPath, clientIP(r))
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+	if body != nil {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
 	var data map[string]interface{}
 	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
 		http.Error(w, "Failed to decode JSON payload", http.StatusBadRequest)
@@ -67,7 +90,7 @@ func handleWebhook(w http.ResponseWriter, r *http.Request) {
 
 	message := processJSONData(data) // Process JSON data and convert it to a message
 
-	sendToTelegram(WebhookPath, message) // Send the message to Telegram
+	dispatchMessage(WebhookPath, outboundMessage{Text: message, NeedsEscape: true}) // Send the message to every configured destination
 
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintln(w, "Webhook request processed")
@@ -89,22 +112,68 @@ func handleHeartcheck(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintln(w, "Heartcheck request processed")
 }
 
-func createDynamicHandler(filterKeys []string, path string) http.HandlerFunc {
+func createDynamicHandler(filterKeys []string, path string, tmpl *template.Template) http.HandlerFunc {
+	mediaKey := mediaKeyForPath(path)
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "Invalid HTTP method", http.StatusMethodNotAllowed)
 			return
 		}
 
+		body, ok := verifySignature(r, path)
+		if !ok {
+			log.Printf("Rejected request to /%s from %s: signature verification failed", path, clientIP(r))
+			http.Error(w, "Invalid signature", http.StatusUnauthorized)
+			return
+		}
+		if body != nil {
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		if mediaKey != "" && strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			handleMultipartMedia(w, r, path, mediaKey)
+			return
+		}
+
 		var data map[string]interface{}
 		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
 			http.Error(w, "Failed to decode JSON payload", http.StatusBadRequest)
 			return
 		}
 
-		message := processJSONDataWithFilterKeys(data, filterKeys) // Process JSON data with filter keys and convert it to a message
+		var message string
+		needsEscape := false
+		if tmpl != nil {
+			rendered, err := renderTemplate(tmpl, data)
+			if err != nil {
+				log.Printf("Failed to render template for path %s: %v", path, err)
+				http.Error(w, "Failed to render template", http.StatusInternalServerError)
+				return
+			}
+			message = rendered
+		} else {
+			message = processJSONDataWithFilterKeys(data, filterKeys) // Process JSON data with filter keys and convert it to a message
+			needsEscape = true
+		}
+
+		if mediaKey != "" {
+			ctx, cancel := context.WithTimeout(r.Context(), sinkTimeout)
+			handled, err := handleJSONMedia(ctx, mediaKey, data, message, needsEscape)
+			cancel()
+			if err != nil {
+				log.Printf("Failed to send media for path %s: %v", path, err)
+				http.Error(w, "Failed to send media", http.StatusInternalServerError)
+				return
+			}
+			if handled {
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprintln(w, "Dynamic request processed")
+				return
+			}
+		}
 
-		sendToTelegram(path, message) // Send the message to Telegram
+		dispatchMessage(path, outboundMessage{Text: message, NeedsEscape: needsEscape}) // Send the message to every configured destination
 
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprintln(w, "Dynamic request processed")
@@ -154,27 +223,38 @@ func processJSONKeyValue(key string, value interface{}, level int) string {
 	return message
 }
 
-func sendToTelegram(path, message string) {
-	if TGAPIToken == "" || TGChatID == "" {
-		log.Println("Telegram API token or chat ID is missing.")
-		return
+// parseModeFromEnv reads the PARSE_MODE env var, defaulting to no parse mode
+// (plain text) for anything other than the two modes Telegram supports.
+func parseModeFromEnv() telegram.ParseMode {
+	switch strings.ToUpper(os.Getenv("PARSE_MODE")) {
+	case "HTML":
+		return telegram.ParseModeHTML
+	case "MARKDOWNV2":
+		return telegram.ParseModeMarkdownV2
+	default:
+		return telegram.ParseModeNone
 	}
+}
 
-	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", TGAPIToken)
-	payload := fmt.Sprintf(`{"chat_id": "%s", "text": "%s"}`, TGChatID, message)
-
-	resp, err := http.Post(apiURL, "application/json", strings.NewReader(payload)) // Send a POST request to the Telegram API
-	if err != nil {
-		log.Printf("Failed to send message to Telegram: %v", err)
-		return
-	}
-	defer resp.Body.Close()
+// envFlag reports whether the per-path boolean env var {PATH}_{suffix} is set
+// to "true", following the same {PATH}_FILTER_KEY naming convention used
+// elsewhere for per-path configuration.
+func envFlag(path, suffix string) bool {
+	name := strings.ToUpper(path) + "_" + suffix
+	return strings.EqualFold(os.Getenv(name), "true")
+}
 
-	if resp.StatusCode != http.StatusOK {
-		respData, _ := ioutil.ReadAll(resp.Body)
-		log.Printf("Failed to send message to Telegram. Status code: %d\nResponse Body: %s", resp.StatusCode, respData)
-		return
+// escapeForParseMode escapes message for mode, leaving plain text untouched.
+// Messages rendered from a per-path template are expected to call escapeMD /
+// escapeHTML themselves where needed, so this only applies to the default
+// flattener output.
+func escapeForParseMode(mode telegram.ParseMode, message string) string {
+	switch mode {
+	case telegram.ParseModeMarkdownV2:
+		return telegram.EscapeMarkdownV2(message)
+	case telegram.ParseModeHTML:
+		return telegram.EscapeHTML(message)
+	default:
+		return message
 	}
-
-	log.Printf("Message sent to Telegram for path: %s", path)
 }
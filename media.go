@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sppo555/webhook/telegram"
+)
+
+// multipartMaxMemory bounds how much of an incoming multipart upload
+// ParseMultipartForm keeps in memory; anything beyond it spills to a temp
+// file on disk instead of being buffered whole.
+const multipartMaxMemory = 10 << 20 // 10MB
+
+// mediaKeyForPath returns the JSON key {PATH}_MEDIA_KEY declares as carrying
+// an attachment for path, or "" if the path isn't configured for media.
+func mediaKeyForPath(path string) string {
+	return os.Getenv(strings.ToUpper(path) + "_MEDIA_KEY")
+}
+
+// handleMultipartMedia reads the mediaKey file part out of a
+// multipart/form-data request and uploads it to Telegram, captioned with
+// the rest of the form fields flattened the same way a JSON body would be.
+func handleMultipartMedia(w http.ResponseWriter, r *http.Request, path, mediaKey string) {
+	if err := r.ParseMultipartForm(multipartMaxMemory); err != nil {
+		http.Error(w, "Failed to parse multipart payload", http.StatusBadRequest)
+		return
+	}
+
+	file, header, err := r.FormFile(mediaKey)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Missing file part %q", mediaKey), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	mimeType, wrapped, err := sniffContentType(file)
+	if err != nil {
+		log.Printf("Failed to read media upload for path %s: %v", path, err)
+		http.Error(w, "Failed to read upload", http.StatusBadRequest)
+		return
+	}
+
+	caption := processJSONData(formValuesToData(r.MultipartForm.Value))
+	mediaFile := telegram.MediaFile{Filename: header.Filename, Reader: wrapped}
+
+	ctx, cancel := context.WithTimeout(r.Context(), sinkTimeout)
+	defer cancel()
+
+	if err := sendMediaFile(ctx, TGChatID, mediaFile, mimeType, header.Size, caption, true); err != nil {
+		log.Printf("Failed to send media for path %s: %v", path, err)
+		http.Error(w, "Failed to send media", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, "Media request processed")
+}
+
+// handleJSONMedia looks for mediaKey in data and, if present, sends it as
+// Telegram media (a URL is forwarded as-is; anything else is treated as a
+// base64-encoded blob). handled is false when mediaKey is absent so the
+// caller falls back to a regular text message.
+func handleJSONMedia(ctx context.Context, mediaKey string, data map[string]interface{}, caption string, needsEscape bool) (handled bool, err error) {
+	raw, ok := data[mediaKey]
+	if !ok {
+		return false, nil
+	}
+	value, ok := raw.(string)
+	if !ok || value == "" {
+		return false, nil
+	}
+
+	if strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") {
+		return true, sendMediaURL(ctx, TGChatID, value, caption, needsEscape)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return true, fmt.Errorf("media: %q is neither a URL nor valid base64: %w", mediaKey, err)
+	}
+
+	mimeType := http.DetectContentType(decoded)
+	file := telegram.MediaFile{Filename: mediaKey + extensionForMIME(mimeType), Reader: bytes.NewReader(decoded)}
+	return true, sendMediaFile(ctx, TGChatID, file, mimeType, int64(len(decoded)), caption, needsEscape)
+}
+
+// sendMediaFile picks sendPhoto/sendDocument/sendVideo based on mimeType and
+// enforces Telegram's per-kind size limit before uploading. caption is
+// escaped for ParseMode here, matching telegramSink.Send, unless needsEscape
+// is false because caption was already rendered by a per-path template.
+func sendMediaFile(ctx context.Context, chatID string, file telegram.MediaFile, mimeType string, size int64, caption string, needsEscape bool) error {
+	kind, limit := classifyMedia(mimeType)
+	if size > limit {
+		return fmt.Errorf("media: %d bytes exceeds the %d byte limit for a %s", size, limit, kind)
+	}
+	if needsEscape {
+		caption = escapeForParseMode(ParseMode, caption)
+	}
+
+	opts := telegram.SendOptions{ParseMode: ParseMode}
+	switch kind {
+	case "photo":
+		return tgClient.UploadPhoto(ctx, chatID, file, caption, opts)
+	case "video":
+		return tgClient.UploadVideo(ctx, chatID, file, caption, opts)
+	default:
+		return tgClient.UploadDocument(ctx, chatID, file, caption, opts)
+	}
+}
+
+// sendMediaURL lets Telegram fetch mediaURL itself, guessing the kind from
+// its file extension since there are no bytes here to sniff.
+func sendMediaURL(ctx context.Context, chatID, mediaURL, caption string, needsEscape bool) error {
+	ext := filepath.Ext(mediaURL)
+	if u, err := url.Parse(mediaURL); err == nil {
+		ext = filepath.Ext(u.Path)
+	}
+	kind, _ := classifyMedia(mime.TypeByExtension(ext))
+	if needsEscape {
+		caption = escapeForParseMode(ParseMode, caption)
+	}
+	opts := telegram.SendOptions{ParseMode: ParseMode}
+
+	switch kind {
+	case "photo":
+		return tgClient.SendPhoto(ctx, chatID, mediaURL, caption, opts)
+	case "video":
+		return tgClient.SendVideo(ctx, chatID, mediaURL, caption, opts)
+	default:
+		return tgClient.SendDocument(ctx, chatID, mediaURL, caption, opts)
+	}
+}
+
+// classifyMedia maps a sniffed or declared MIME type to the Telegram send
+// method it belongs with and the size limit that method enforces.
+func classifyMedia(mimeType string) (kind string, limit int64) {
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return "photo", telegram.MaxPhotoBytes
+	case strings.HasPrefix(mimeType, "video/"):
+		return "video", telegram.MaxDocumentBytes
+	default:
+		return "document", telegram.MaxDocumentBytes
+	}
+}
+
+// sniffContentType peeks up to 512 bytes of r to detect its MIME type, then
+// returns a reader that still yields those bytes followed by the rest of r,
+// so the caller never has to buffer the whole upload to classify it.
+func sniffContentType(r io.Reader) (mimeType string, wrapped io.Reader, err error) {
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", nil, err
+	}
+	return http.DetectContentType(buf[:n]), io.MultiReader(bytes.NewReader(buf[:n]), r), nil
+}
+
+// formValuesToData flattens a multipart form's text fields into the same
+// shape processJSONData expects, taking the first value of each field.
+func formValuesToData(values map[string][]string) map[string]interface{} {
+	data := make(map[string]interface{}, len(values))
+	for key, vals := range values {
+		if len(vals) > 0 {
+			data[key] = vals[0]
+		}
+	}
+	return data
+}
+
+func extensionForMIME(mimeType string) string {
+	exts, err := mime.ExtensionsByType(mimeType)
+	if err != nil || len(exts) == 0 {
+		return ""
+	}
+	return exts[0]
+}
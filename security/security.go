@@ -0,0 +1,68 @@
+// Package security verifies webhook request signatures: GitHub's
+// X-Hub-Signature-256, GitLab's X-Gitlab-Token, and a generic timestamped
+// HMAC scheme.
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultReplayWindow is how old a generic-scheme timestamp may be before a
+// request is rejected as a replay.
+const DefaultReplayWindow = 5 * time.Minute
+
+// ConstantTimeEqual reports whether a and b are equal, without leaking
+// timing information about where they first differ.
+func ConstantTimeEqual(a, b string) bool {
+	return hmac.Equal([]byte(a), []byte(b))
+}
+
+// VerifyGitHubSignature checks a GitHub-style "X-Hub-Signature-256:
+// sha256=<hex>" header against an HMAC-SHA256 of body keyed by secret.
+func VerifyGitHubSignature(secret, header string, body []byte) bool {
+	const prefix = "sha256="
+	if !strings.HasPrefix(header, prefix) {
+		return false
+	}
+	return ConstantTimeEqual(strings.TrimPrefix(header, prefix), hexHMAC(secret, body))
+}
+
+// VerifyGitLabToken checks a GitLab-style "X-Gitlab-Token" header for
+// equality with secret.
+func VerifyGitLabToken(secret, header string) bool {
+	return ConstantTimeEqual(header, secret)
+}
+
+// VerifyGenericSignature checks an "X-Signature" header, an HMAC-SHA256 of
+// "<timestamp>.<body>" keyed by secret, against the Unix timestamp in
+// timestampHeader. It reports an error if timestampHeader is unparsable or
+// older than window.
+func VerifyGenericSignature(secret, signatureHeader, timestampHeader string, body []byte, window time.Duration) (bool, error) {
+	seconds, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("security: invalid X-Timestamp %q: %w", timestampHeader, err)
+	}
+
+	age := time.Since(time.Unix(seconds, 0))
+	if age < 0 {
+		age = -age
+	}
+	if age > window {
+		return false, fmt.Errorf("security: timestamp %s is outside the %s replay window", timestampHeader, window)
+	}
+
+	signed := timestampHeader + "." + string(body)
+	return ConstantTimeEqual(signatureHeader, hexHMAC(secret, []byte(signed))), nil
+}
+
+func hexHMAC(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
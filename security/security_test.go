@@ -0,0 +1,99 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func sign(secret string, data []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyGitHubSignature(t *testing.T) {
+	secret := "s3cr3t"
+	body := []byte(`{"ref":"refs/heads/main"}`)
+	valid := "sha256=" + sign(secret, body)
+
+	if !VerifyGitHubSignature(secret, valid, body) {
+		t.Errorf("expected valid signature %q to verify", valid)
+	}
+	if VerifyGitHubSignature(secret, "sha256=deadbeef", body) {
+		t.Error("expected mismatched signature to fail verification")
+	}
+	if VerifyGitHubSignature(secret, sign(secret, body), body) {
+		t.Error("expected signature without the sha256= prefix to fail verification")
+	}
+	if VerifyGitHubSignature("wrong-secret", valid, body) {
+		t.Error("expected signature computed with a different secret to fail verification")
+	}
+}
+
+func TestVerifyGitLabToken(t *testing.T) {
+	secret := "s3cr3t"
+
+	if !VerifyGitLabToken(secret, secret) {
+		t.Error("expected matching GitLab token to verify")
+	}
+	if VerifyGitLabToken(secret, "wrong-token") {
+		t.Error("expected mismatched GitLab token to fail verification")
+	}
+}
+
+func TestVerifyGenericSignature(t *testing.T) {
+	secret := "s3cr3t"
+	body := []byte(`{"status":"firing"}`)
+
+	t.Run("valid signature within the replay window", func(t *testing.T) {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		signature := sign(secret, []byte(timestamp+"."+string(body)))
+
+		ok, err := VerifyGenericSignature(secret, signature, timestamp, body, DefaultReplayWindow)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !ok {
+			t.Error("expected valid signature to verify")
+		}
+	})
+
+	t.Run("mismatched signature", func(t *testing.T) {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+		ok, err := VerifyGenericSignature(secret, "deadbeef", timestamp, body, DefaultReplayWindow)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ok {
+			t.Error("expected mismatched signature to fail verification")
+		}
+	})
+
+	t.Run("expired timestamp", func(t *testing.T) {
+		timestamp := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+		signature := sign(secret, []byte(timestamp+"."+string(body)))
+
+		ok, err := VerifyGenericSignature(secret, signature, timestamp, body, DefaultReplayWindow)
+		if err == nil {
+			t.Fatal("expected an error for a timestamp outside the replay window")
+		}
+		if ok {
+			t.Error("expected expired timestamp to fail verification")
+		}
+	})
+
+	t.Run("unparsable timestamp", func(t *testing.T) {
+		ok, err := VerifyGenericSignature(secret, "anything", "not-a-unix-timestamp", body, DefaultReplayWindow)
+		if err == nil {
+			t.Fatal("expected an error for an unparsable timestamp")
+		}
+		if ok {
+			t.Error("expected unparsable timestamp to fail verification")
+		}
+	})
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sppo555/webhook/telegram"
+)
+
+// sinkTimeout bounds how long a single destination gets to accept a message
+// before dispatchMessage gives up on it.
+const sinkTimeout = 10 * time.Second
+
+// Sink delivers a rendered message for path to one destination.
+type Sink interface {
+	Send(ctx context.Context, path string, msg outboundMessage) error
+}
+
+// outboundMessage is what dispatchMessage hands to every sink. NeedsEscape
+// tells telegramSink whether Text is raw flattener output that still needs
+// parse-mode escaping, or has already been finalized (a per-path template,
+// which is expected to call escapeMD/escapeHTML itself where needed). Other
+// sinks ignore NeedsEscape and always use Text as-is, so they receive plain
+// text rather than Telegram-specific MarkdownV2/HTML escaping.
+type outboundMessage struct {
+	Text        string
+	NeedsEscape bool
+}
+
+// labeledSink pairs a Sink with the label a template can target it by (see
+// extractDestinationLabel).
+type labeledSink struct {
+	label string
+	sink  Sink
+}
+
+// dispatchMessage sends msg to every destination configured for path,
+// concurrently. If msg.Text was prefixed with "@destination:<label>\n"
+// (which a template can do to route by severity), only sinks with a
+// matching label receive it; otherwise it goes to all of them.
+func dispatchMessage(path string, msg outboundMessage) {
+	key := normalizePath(path)
+	if mutedPaths.isMuted(key) {
+		log.Printf("Skipping forward for muted path %s", key)
+		return
+	}
+
+	label, text := extractDestinationLabel(msg.Text)
+	msg.Text = text
+	forwardLog.add(key, msg.Text)
+
+	sinks, err := destinationsForPath(path)
+	if err != nil {
+		log.Printf("Failed to resolve destinations for path %s: %v", path, err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	matched := false
+	for _, ls := range sinks {
+		if label != "" && !strings.EqualFold(ls.label, label) {
+			continue
+		}
+		matched = true
+
+		wg.Add(1)
+		go func(ls labeledSink) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), sinkTimeout)
+			defer cancel()
+
+			if err := ls.sink.Send(ctx, path, msg); err != nil {
+				log.Printf("Failed to send to destination %q for path %s: %v", ls.label, path, err)
+				return
+			}
+			log.Printf("Sent message to destination %q for path %s", ls.label, path)
+		}(ls)
+	}
+	if label != "" && !matched {
+		log.Printf("No destination labeled %q configured for path %s; message was dropped", label, path)
+	}
+	wg.Wait()
+}
+
+// extractDestinationLabel strips a leading "@destination:<label>\n" line
+// from message, if present, and returns the label and remaining text.
+func extractDestinationLabel(message string) (label, rest string) {
+	const prefix = "@destination:"
+	if !strings.HasPrefix(message, prefix) {
+		return "", message
+	}
+
+	body := message[len(prefix):]
+	newline := strings.IndexByte(body, '\n')
+	if newline == -1 {
+		return "", message
+	}
+	return strings.TrimSpace(body[:newline]), body[newline+1:]
+}
+
+// destinationsForPath resolves {PATH}_DESTINATIONS, a comma-separated list of
+// "[label=]scheme:value" entries, into sinks. A path with no destinations
+// configured falls back to the single global Telegram chat, preserving the
+// pre-existing behavior.
+func destinationsForPath(path string) ([]labeledSink, error) {
+	spec := os.Getenv(strings.ToUpper(path) + "_DESTINATIONS")
+	if spec == "" {
+		return []labeledSink{{label: "default", sink: &telegramSink{chatID: TGChatID, client: tgClient}}}, nil
+	}
+
+	var sinks []labeledSink
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		// Only treat "=" as a label separator when it appears before the
+		// scheme's ":", so a scheme value containing "=" (e.g. a Discord
+		// webhook URL with a "?wait=true" query string) isn't mistaken for
+		// a label.
+		label, rest := "default", entry
+		if colon := strings.IndexByte(entry, ':'); colon != -1 {
+			if eq := strings.IndexByte(entry[:colon], '='); eq != -1 {
+				label, rest = entry[:eq], entry[eq+1:]
+			}
+		}
+
+		sink, err := newSink(rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid destination %q: %w", entry, err)
+		}
+		sinks = append(sinks, labeledSink{label: label, sink: sink})
+	}
+	return sinks, nil
+}
+
+func newSink(spec string) (Sink, error) {
+	scheme, value, ok := strings.Cut(spec, ":")
+	if !ok {
+		return nil, fmt.Errorf("missing scheme in %q", spec)
+	}
+
+	switch scheme {
+	case "tg":
+		chatID, threadID, err := parseTelegramTarget(value)
+		if err != nil {
+			return nil, err
+		}
+		return &telegramSink{chatID: chatID, threadID: threadID, client: tgClient}, nil
+	case "slack":
+		return &webhookSink{url: value, build: buildSlackPayload}, nil
+	case "discord":
+		return &webhookSink{url: value, build: buildDiscordPayload}, nil
+	case "email":
+		return &emailSink{to: value}, nil
+	default:
+		return nil, fmt.Errorf("unknown destination scheme %q", scheme)
+	}
+}
+
+// parseTelegramTarget splits a "tg:" destination value into its chat ID (or
+// @channelname) and an optional forum message_thread_id.
+func parseTelegramTarget(value string) (chatID string, threadID int, err error) {
+	chatID, thread, ok := strings.Cut(value, ":")
+	if !ok {
+		return value, 0, nil
+	}
+
+	threadID, err = strconv.Atoi(thread)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid message_thread_id %q: %w", thread, err)
+	}
+	return chatID, threadID, nil
+}
+
+// telegramSink delivers to a Telegram chat, optionally a specific forum
+// topic within it.
+type telegramSink struct {
+	chatID   string
+	threadID int
+	client   *telegram.Client
+}
+
+func (s *telegramSink) Send(ctx context.Context, path string, msg outboundMessage) error {
+	if s.client == nil || s.chatID == "" {
+		return fmt.Errorf("telegram sink: chat ID is missing")
+	}
+
+	text := msg.Text
+	if msg.NeedsEscape {
+		text = escapeForParseMode(ParseMode, text)
+	}
+
+	opts := telegram.SendOptions{
+		ParseMode:             ParseMode,
+		DisableWebPagePreview: envFlag(path, "DISABLE_WEB_PAGE_PREVIEW"),
+		DisableNotification:   envFlag(path, "DISABLE_NOTIFICATION"),
+		MessageThreadID:       s.threadID,
+	}
+	return s.client.SendMessage(ctx, s.chatID, text, opts)
+}
+
+// webhookSink POSTs a JSON payload to a chat-app incoming webhook URL
+// (Slack, Discord, ...); build shapes the payload for that app.
+type webhookSink struct {
+	url   string
+	build func(message string) ([]byte, error)
+}
+
+func (s *webhookSink) Send(ctx context.Context, path string, msg outboundMessage) error {
+	body, err := s.build(msg.Text)
+	if err != nil {
+		return fmt.Errorf("webhook sink: failed to build payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook sink: failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook sink: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook sink: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func buildSlackPayload(message string) ([]byte, error) {
+	return json.Marshal(map[string]string{"text": message})
+}
+
+func buildDiscordPayload(message string) ([]byte, error) {
+	return json.Marshal(map[string]string{"content": message})
+}
+
+// emailSink delivers via SMTP, configured globally through SMTP_HOST,
+// SMTP_PORT, SMTP_FROM, and optionally SMTP_USER/SMTP_PASS.
+type emailSink struct {
+	to string
+}
+
+func (s *emailSink) Send(ctx context.Context, path string, msg outboundMessage) error {
+	host := os.Getenv("SMTP_HOST")
+	port := os.Getenv("SMTP_PORT")
+	from := os.Getenv("SMTP_FROM")
+	if host == "" || port == "" || from == "" {
+		return fmt.Errorf("email sink: SMTP_HOST, SMTP_PORT, and SMTP_FROM must be set")
+	}
+
+	var auth smtp.Auth
+	if user := os.Getenv("SMTP_USER"); user != "" {
+		auth = smtp.PlainAuth("", user, os.Getenv("SMTP_PASS"), host)
+	}
+
+	body := fmt.Sprintf("Subject: [%s] webhook alert\r\nTo: %s\r\n\r\n%s\r\n", path, s.to, msg.Text)
+
+	return smtp.SendMail(host+":"+port, auth, from, []string{s.to}, []byte(body))
+}
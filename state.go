@@ -0,0 +1,65 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// forwardRing keeps the last ringBufferSize messages forwarded for each
+// path, used to answer the Telegram /last command.
+type forwardRing struct {
+	mu    sync.Mutex
+	items map[string][]string
+}
+
+func newForwardRing() *forwardRing {
+	return &forwardRing{items: make(map[string][]string)}
+}
+
+func (r *forwardRing) add(path, message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	items := append(r.items[path], message)
+	if len(items) > ringBufferSize {
+		items = items[len(items)-ringBufferSize:]
+	}
+	r.items[path] = items
+}
+
+func (r *forwardRing) last(path string, n int) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	items := r.items[path]
+	if len(items) > n {
+		items = items[len(items)-n:]
+	}
+	out := make([]string, len(items))
+	copy(out, items)
+	return out
+}
+
+// muteState tracks, per path, how long forwarding should be suppressed for.
+type muteState struct {
+	mu    sync.Mutex
+	until map[string]time.Time
+}
+
+func newMuteState() *muteState {
+	return &muteState{until: make(map[string]time.Time)}
+}
+
+func (m *muteState) mute(path string, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.until[path] = time.Now().Add(duration)
+}
+
+func (m *muteState) isMuted(path string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	until, ok := m.until[path]
+	return ok && time.Now().Before(until)
+}
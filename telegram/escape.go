@@ -0,0 +1,34 @@
+package telegram
+
+import "strings"
+
+// markdownV2Special lists the characters MarkdownV2 requires to be escaped
+// with a backslash outside of entities.
+// See https://core.telegram.org/bots/api#markdownv2-style
+const markdownV2Special = "_*[]()~`>#+-=|{}.!\\"
+
+// EscapeMarkdownV2 backslash-escapes s so it is safe to embed as literal text
+// in a MarkdownV2 message.
+func EscapeMarkdownV2(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if strings.ContainsRune(markdownV2Special, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+var htmlReplacer = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+)
+
+// EscapeHTML escapes s so it is safe to embed as literal text in an HTML-mode
+// message, per https://core.telegram.org/bots/api#html-style.
+func EscapeHTML(s string) string {
+	return htmlReplacer.Replace(s)
+}
@@ -0,0 +1,316 @@
+// Package telegram is a small client for the subset of the Telegram Bot API
+// this service needs: sending/forwarding messages and photos with retries.
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ParseMode selects how Telegram should parse message text.
+type ParseMode string
+
+const (
+	ParseModeNone       ParseMode = ""
+	ParseModeHTML       ParseMode = "HTML"
+	ParseModeMarkdownV2 ParseMode = "MarkdownV2"
+)
+
+const (
+	// MaxMessageLength is Telegram's hard limit on sendMessage text length.
+	MaxMessageLength = 4096
+
+	defaultBaseURL = "https://api.telegram.org"
+	maxRetries     = 3
+)
+
+// APIResponse mirrors the envelope Telegram wraps every Bot API response in.
+type APIResponse struct {
+	Ok          bool                `json:"ok"`
+	ErrorCode   int                 `json:"error_code,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Parameters  *ResponseParameters `json:"parameters,omitempty"`
+	Result      json.RawMessage     `json:"result,omitempty"`
+}
+
+// ResponseParameters carries extra info Telegram attaches to failed calls.
+type ResponseParameters struct {
+	RetryAfter int `json:"retry_after,omitempty"`
+}
+
+// SendOptions configures optional per-message behavior.
+type SendOptions struct {
+	ParseMode             ParseMode
+	DisableWebPagePreview bool
+	DisableNotification   bool
+	MessageThreadID       int // forum topic, 0 means none
+}
+
+// Client is a minimal Telegram Bot API client.
+type Client struct {
+	token      string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient returns a Client authenticated with token. An empty token yields
+// a Client whose calls always fail, mirroring how the rest of this service
+// treats missing configuration.
+//
+// The returned Client has no fixed request timeout; callers are expected to
+// bound calls via the context they pass in, since long-polling (GetUpdates)
+// needs a deadline well beyond what a typical send should get.
+func NewClient(token string) *Client {
+	return &Client{
+		token:      token,
+		baseURL:    defaultBaseURL,
+		httpClient: &http.Client{},
+	}
+}
+
+// SendMessage sends text to chatID, chunking it on MaxMessageLength boundaries
+// if necessary. It returns the first error encountered, after which no
+// further chunks are sent.
+func (c *Client) SendMessage(ctx context.Context, chatID, text string, opts SendOptions) error {
+	for _, chunk := range chunkMessage(text, MaxMessageLength) {
+		values := url.Values{
+			"chat_id": {chatID},
+			"text":    {chunk},
+		}
+		applySendOptions(values, opts)
+
+		if _, err := c.call(ctx, "sendMessage", values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SendPhoto sends the photo at photoURL (a URL or a file_id) to chatID with
+// an optional caption.
+func (c *Client) SendPhoto(ctx context.Context, chatID, photoURL, caption string, opts SendOptions) error {
+	values := url.Values{
+		"chat_id": {chatID},
+		"photo":   {photoURL},
+	}
+	if caption != "" {
+		values.Set("caption", caption)
+	}
+	applySendOptions(values, opts)
+
+	_, err := c.call(ctx, "sendPhoto", values)
+	return err
+}
+
+// SendDocument sends the document at documentURL (a URL or a file_id) to
+// chatID with an optional caption.
+func (c *Client) SendDocument(ctx context.Context, chatID, documentURL, caption string, opts SendOptions) error {
+	values := url.Values{
+		"chat_id":  {chatID},
+		"document": {documentURL},
+	}
+	if caption != "" {
+		values.Set("caption", caption)
+	}
+	applySendOptions(values, opts)
+
+	_, err := c.call(ctx, "sendDocument", values)
+	return err
+}
+
+// SendVideo sends the video at videoURL (a URL or a file_id) to chatID with
+// an optional caption.
+func (c *Client) SendVideo(ctx context.Context, chatID, videoURL, caption string, opts SendOptions) error {
+	values := url.Values{
+		"chat_id": {chatID},
+		"video":   {videoURL},
+	}
+	if caption != "" {
+		values.Set("caption", caption)
+	}
+	applySendOptions(values, opts)
+
+	_, err := c.call(ctx, "sendVideo", values)
+	return err
+}
+
+// ForwardMessage forwards messageID from fromChatID into toChatID.
+func (c *Client) ForwardMessage(ctx context.Context, toChatID, fromChatID string, messageID int) error {
+	values := url.Values{
+		"chat_id":      {toChatID},
+		"from_chat_id": {fromChatID},
+		"message_id":   {strconv.Itoa(messageID)},
+	}
+
+	_, err := c.call(ctx, "forwardMessage", values)
+	return err
+}
+
+func applySendOptions(values url.Values, opts SendOptions) {
+	if opts.ParseMode != ParseModeNone {
+		values.Set("parse_mode", string(opts.ParseMode))
+	}
+	if opts.DisableWebPagePreview {
+		values.Set("disable_web_page_preview", "true")
+	}
+	if opts.DisableNotification {
+		values.Set("disable_notification", "true")
+	}
+	if opts.MessageThreadID != 0 {
+		values.Set("message_thread_id", strconv.Itoa(opts.MessageThreadID))
+	}
+}
+
+// call performs a single Bot API method call, retrying on 429 and 5xx
+// responses with exponential backoff (honoring Telegram's retry_after when
+// present) up to maxRetries times.
+func (c *Client) call(ctx context.Context, method string, values url.Values) (*APIResponse, error) {
+	if c.token == "" {
+		return nil, fmt.Errorf("telegram: API token is missing")
+	}
+
+	apiURL := fmt.Sprintf("%s/bot%s/%s", c.baseURL, c.token, method)
+
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			if err := waitForRetry(ctx, lastErr.(retryableError).delay(attempt)); err != nil {
+				return nil, err
+			}
+		}
+
+		result, err := c.doRequest(ctx, apiURL, values)
+		if err == nil {
+			return result, nil
+		}
+
+		retryErr, ok := err.(retryableError)
+		if !ok || attempt == maxRetries {
+			return nil, err
+		}
+		lastErr = retryErr
+	}
+
+	return nil, lastErr
+}
+
+// retryableError wraps a failed call that is worth retrying, carrying the
+// retry_after hint Telegram returns on 429s.
+type retryableError struct {
+	statusCode int
+	retryAfter int
+	apiErr     *APIResponse
+}
+
+func (e retryableError) Error() string {
+	if e.apiErr != nil {
+		return fmt.Sprintf("telegram: request failed with status %d: %s", e.statusCode, e.apiErr.Description)
+	}
+	return fmt.Sprintf("telegram: request failed with status %d", e.statusCode)
+}
+
+func (e retryableError) delay(attempt int) time.Duration {
+	if e.retryAfter > 0 {
+		return time.Duration(e.retryAfter) * time.Second
+	}
+	return time.Duration(1<<uint(attempt-1)) * time.Second // exponential backoff: 1s, 2s, 4s, ...
+}
+
+// waitForRetry sleeps for delay, returning early with ctx.Err() if ctx is
+// cancelled or expires first, so a slow retry_after can't hold a caller
+// (e.g. dispatchMessage's sinkTimeout) past its own deadline.
+func waitForRetry(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Client) doRequest(ctx context.Context, apiURL string, values url.Values) (*APIResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("telegram: failed to build request: %w", err)
+	}
+	req.URL.RawQuery = values.Encode()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("telegram: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	apiResp, err := decodeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if apiResp.Ok {
+		return apiResp, nil
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		retryAfter := 0
+		if apiResp.Parameters != nil {
+			retryAfter = apiResp.Parameters.RetryAfter
+		}
+		return nil, retryableError{statusCode: resp.StatusCode, retryAfter: retryAfter, apiErr: apiResp}
+	}
+
+	return nil, fmt.Errorf("telegram: %s (code %d)", apiResp.Description, apiResp.ErrorCode)
+}
+
+// decodeResponse reads and JSON-decodes resp's body into an APIResponse.
+func decodeResponse(resp *http.Response) (*APIResponse, error) {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("telegram: failed to read response: %w", err)
+	}
+
+	var apiResp APIResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		log.Printf("telegram: failed to decode response (status %d): %v", resp.StatusCode, err)
+		return nil, fmt.Errorf("telegram: failed to decode response: %w", err)
+	}
+	return &apiResp, nil
+}
+
+// chunkMessage splits text into pieces no longer than limit runes, breaking
+// on newlines where possible so formatting isn't split mid-line.
+func chunkMessage(text string, limit int) []string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return []string{text}
+	}
+
+	var chunks []string
+	for len(runes) > 0 {
+		if len(runes) <= limit {
+			chunks = append(chunks, string(runes))
+			break
+		}
+
+		cut := limit
+		for i := limit; i > 0; i-- {
+			if runes[i-1] == '\n' {
+				cut = i
+				break
+			}
+		}
+
+		chunks = append(chunks, string(runes[:cut]))
+		runes = runes[cut:]
+	}
+	return chunks
+}
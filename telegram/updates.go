@@ -0,0 +1,79 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// APIUpdate is one item returned by getUpdates / delivered to a webhook.
+type APIUpdate struct {
+	UpdateID    int          `json:"update_id"`
+	Message     *Message     `json:"message,omitempty"`
+	InlineQuery *InlineQuery `json:"inline_query,omitempty"`
+}
+
+// Message is the subset of Telegram's Message object this service needs.
+type Message struct {
+	MessageID int    `json:"message_id"`
+	Date      int64  `json:"date"`
+	Chat      Chat   `json:"chat"`
+	From      *User  `json:"from,omitempty"`
+	Text      string `json:"text,omitempty"`
+}
+
+// Chat identifies where a Message was sent.
+type Chat struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type"`
+}
+
+// User identifies a Telegram account.
+type User struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username,omitempty"`
+}
+
+// InlineQuery is Telegram's inline-mode query object.
+type InlineQuery struct {
+	ID    string `json:"id"`
+	Query string `json:"query"`
+	From  User   `json:"from"`
+}
+
+// GetUpdates long-polls Telegram for new updates starting at offset, waiting
+// up to timeoutSeconds for one to arrive. Callers should bound ctx with a
+// deadline comfortably longer than timeoutSeconds.
+func (c *Client) GetUpdates(ctx context.Context, offset, timeoutSeconds int) ([]APIUpdate, error) {
+	values := url.Values{
+		"offset":  {strconv.Itoa(offset)},
+		"timeout": {strconv.Itoa(timeoutSeconds)},
+	}
+
+	resp, err := c.call(ctx, "getUpdates", values)
+	if err != nil {
+		return nil, err
+	}
+
+	var updates []APIUpdate
+	if err := json.Unmarshal(resp.Result, &updates); err != nil {
+		return nil, fmt.Errorf("telegram: failed to decode updates: %w", err)
+	}
+	return updates, nil
+}
+
+// SetWebhook registers webhookURL with Telegram as the push target for
+// future updates, replacing long-polling.
+func (c *Client) SetWebhook(ctx context.Context, webhookURL string) error {
+	_, err := c.call(ctx, "setWebhook", url.Values{"url": {webhookURL}})
+	return err
+}
+
+// DeleteWebhook removes any webhook previously registered with SetWebhook,
+// which is required before GetUpdates will work again.
+func (c *Client) DeleteWebhook(ctx context.Context) error {
+	_, err := c.call(ctx, "deleteWebhook", url.Values{})
+	return err
+}
@@ -0,0 +1,114 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+)
+
+// Size limits Telegram enforces on uploaded media.
+// See https://core.telegram.org/bots/api#sending-files
+const (
+	MaxPhotoBytes    = 10 << 20 // 10MB
+	MaxDocumentBytes = 50 << 20 // 50MB
+)
+
+// MediaFile is raw file content to upload. Reader is streamed directly into
+// the outgoing multipart request rather than buffered in memory, so it can
+// be an *os.File, a request body, or any other io.Reader.
+type MediaFile struct {
+	Filename string
+	Reader   io.Reader
+}
+
+// UploadPhoto streams file to chatID as a photo.
+func (c *Client) UploadPhoto(ctx context.Context, chatID string, file MediaFile, caption string, opts SendOptions) error {
+	return c.uploadMedia(ctx, "sendPhoto", "photo", chatID, file, caption, opts)
+}
+
+// UploadDocument streams file to chatID as a generic document.
+func (c *Client) UploadDocument(ctx context.Context, chatID string, file MediaFile, caption string, opts SendOptions) error {
+	return c.uploadMedia(ctx, "sendDocument", "document", chatID, file, caption, opts)
+}
+
+// UploadVideo streams file to chatID as a video.
+func (c *Client) UploadVideo(ctx context.Context, chatID string, file MediaFile, caption string, opts SendOptions) error {
+	return c.uploadMedia(ctx, "sendVideo", "video", chatID, file, caption, opts)
+}
+
+// uploadMedia posts file as multipart/form-data, piping the multipart writer
+// straight into the HTTP request body (via io.Pipe) so large files are
+// never fully buffered in memory. Unlike call, it is not retried: the file
+// part can only be read once.
+func (c *Client) uploadMedia(ctx context.Context, method, fieldName, chatID string, file MediaFile, caption string, opts SendOptions) error {
+	if c.token == "" {
+		return fmt.Errorf("telegram: API token is missing")
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+
+	go func() {
+		err := writeMediaFields(mw, fieldName, chatID, caption, file, opts)
+		mw.Close()
+		pw.CloseWithError(err)
+	}()
+
+	apiURL := fmt.Sprintf("%s/bot%s/%s", c.baseURL, c.token, method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, pr)
+	if err != nil {
+		return fmt.Errorf("telegram: failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("telegram: upload request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	apiResp, err := decodeResponse(resp)
+	if err != nil {
+		return err
+	}
+	if !apiResp.Ok {
+		return fmt.Errorf("telegram: %s (code %d)", apiResp.Description, apiResp.ErrorCode)
+	}
+	return nil
+}
+
+func writeMediaFields(mw *multipart.Writer, fieldName, chatID, caption string, file MediaFile, opts SendOptions) error {
+	if err := mw.WriteField("chat_id", chatID); err != nil {
+		return err
+	}
+	if caption != "" {
+		if err := mw.WriteField("caption", caption); err != nil {
+			return err
+		}
+	}
+	if opts.ParseMode != ParseModeNone {
+		if err := mw.WriteField("parse_mode", string(opts.ParseMode)); err != nil {
+			return err
+		}
+	}
+	if opts.DisableNotification {
+		if err := mw.WriteField("disable_notification", "true"); err != nil {
+			return err
+		}
+	}
+	if opts.MessageThreadID != 0 {
+		if err := mw.WriteField("message_thread_id", strconv.Itoa(opts.MessageThreadID)); err != nil {
+			return err
+		}
+	}
+
+	part, err := mw.CreateFormFile(fieldName, file.Filename)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, file.Reader)
+	return err
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/sppo555/webhook/telegram"
+)
+
+// TemplatesDir is where per-path templates are looked up when no
+// {PATH}_TEMPLATE env var points at one explicitly.
+const TemplatesDir = "templates"
+
+// templateFuncs are available to every per-path template.
+var templateFuncs = template.FuncMap{
+	"escapeMD":   telegram.EscapeMarkdownV2,
+	"escapeHTML": telegram.EscapeHTML,
+	"default":    defaultFunc,
+	"datefmt":    datefmtFunc,
+	"jsonpath":   jsonpathFunc,
+}
+
+// loadTemplate resolves the template configured for path, in order:
+//  1. the file named by {PATH}_TEMPLATE
+//  2. templates/<path>.tmpl
+//
+// It returns (nil, nil) when neither exists, meaning the caller should fall
+// back to the default flattener.
+func loadTemplate(path string) (*template.Template, error) {
+	file := os.Getenv(strings.ToUpper(path) + "_TEMPLATE")
+	if file == "" {
+		candidate := filepath.Join(TemplatesDir, path+".tmpl")
+		if _, err := os.Stat(candidate); err != nil {
+			return nil, nil
+		}
+		file = candidate
+	}
+
+	tmpl, err := template.New(filepath.Base(file)).Funcs(templateFuncs).ParseFiles(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s for path %s: %w", file, path, err)
+	}
+	return tmpl, nil
+}
+
+// renderTemplate executes tmpl against data and returns the rendered message.
+func renderTemplate(tmpl *template.Template, data map[string]interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// defaultFunc returns val unless it is the zero value (nil, "", or missing
+// from the JSON payload), in which case it returns def.
+func defaultFunc(def, val interface{}) interface{} {
+	switch v := val.(type) {
+	case nil:
+		return def
+	case string:
+		if v == "" {
+			return def
+		}
+	}
+	return val
+}
+
+// datefmtFunc reformats value, which may be an RFC3339 string or a Unix
+// timestamp (seconds), using Go's reference-time layout.
+func datefmtFunc(layout string, value interface{}) (string, error) {
+	t, err := parseTime(value)
+	if err != nil {
+		return "", fmt.Errorf("datefmt: %w", err)
+	}
+	return t.Format(layout), nil
+}
+
+func parseTime(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t, nil
+		}
+		if seconds, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.Unix(seconds, 0).UTC(), nil
+		}
+		return time.Time{}, fmt.Errorf("unrecognized time value %q", v)
+	case float64:
+		return time.Unix(int64(v), 0).UTC(), nil
+	default:
+		return time.Time{}, fmt.Errorf("unsupported time value %v", value)
+	}
+}
+
+// jsonpathFunc walks data along a dotted path such as "alerts.0.labels.job",
+// indexing into maps by key and slices by numeric index.
+func jsonpathFunc(path string, data interface{}) (interface{}, error) {
+	current := data
+	for _, segment := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			val, ok := node[segment]
+			if !ok {
+				return nil, fmt.Errorf("jsonpath: key %q not found", segment)
+			}
+			current = val
+		case []interface{}:
+			idx, err := strconv.Atoi(segment)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return nil, fmt.Errorf("jsonpath: invalid index %q", segment)
+			}
+			current = node[idx]
+		default:
+			return nil, fmt.Errorf("jsonpath: cannot index into %T at %q", current, segment)
+		}
+	}
+	return current, nil
+}